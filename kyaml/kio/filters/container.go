@@ -0,0 +1,262 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/runtime"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// containerAnnotation may be set on a function config to explicitly identify
+// the container image to run, overriding the apiVersion based lookup.
+const containerAnnotation = "kyaml.kustomize.dev/container"
+
+// ContainerFilter filters Resources using a container image.
+// The container must start a process that reads a ResourceList from
+// stdin, holding the input Resources as its items and the Configuration
+// as its functionConfig, and writes the filtered Resources to stdout.
+// If there is a error or validation failure, the process must exit
+// non-zero.
+// Unless EnvAllowlist is set, the full set of environment variables
+// from the parent process are passed to the container.
+type ContainerFilter struct {
+	// Image is the container image to use to create a container. It is
+	// ignored when Config carries a runtime.FunctionAnnotation selecting a
+	// different runtime.
+	Image string `yaml:"image,omitempty"`
+
+	// Config is the API configuration for the container and passed to the
+	// container as the functionConfig of the ResourceList on its stdin.
+	// Typically a Kubernetes style Resource Config.
+	Config *yaml.RNode `yaml:"config,omitempty"`
+
+	// Timeout bounds how long a single invocation of the function is
+	// allowed to run. If it has not exited before the timeout elapses, its
+	// process is terminated and Filter returns an error wrapping
+	// context.DeadlineExceeded. A zero Timeout means no deadline is
+	// applied.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// StorageMounts are bind/volume/tmpfs mounts exposed to the container,
+	// e.g. so a function can read a mounted kustomization directory or
+	// write large manifests without going through stdin/stdout.
+	StorageMounts []runtime.Mount `yaml:"mounts,omitempty"`
+
+	// Network is the container's network mode: none (the default), host,
+	// bridge, or a user-defined Docker network name. Leave unset unless
+	// the function legitimately needs connectivity, e.g. to fetch remote
+	// bases.
+	Network string `yaml:"network,omitempty"`
+
+	// EnvAllowlist restricts which of the parent process's environment
+	// variables are passed to the container. When empty, the full
+	// process environment is passed through.
+	EnvAllowlist []string `yaml:"envAllowlist,omitempty"`
+
+	// MemoryLimit caps the container's memory, e.g. "512m". Empty means
+	// no limit.
+	MemoryLimit string `yaml:"memoryLimit,omitempty"`
+
+	// CPULimit caps the container's CPU, e.g. "0.5". Empty means no
+	// limit.
+	CPULimit string `yaml:"cpuLimit,omitempty"`
+
+	// AdditionalCapabilities are Linux capabilities added to the
+	// container beyond Docker's default set.
+	AdditionalCapabilities []string `yaml:"additionalCapabilities,omitempty"`
+
+	// ParallelSafe opts this ContainerFilter instance into kio.Pipeline's
+	// parallel dispatch: when true, IsParallelSafe reports that it's safe
+	// to run one invocation per Resource group concurrently, bounded by
+	// Pipeline.Parallelism. It defaults to false, since a function that
+	// needs the full ResourceList to do cross-Resource validation (e.g.
+	// checking uniqueness or referential integrity) would silently see
+	// only a partial, sharded view if parallelized. Set this only for
+	// functions whose invocations are independent across groups.
+	ParallelSafe bool `yaml:"parallelSafe,omitempty"`
+
+	// runtime overrides the runtime.FunctionRuntime used to invoke the
+	// function, bypassing both Image and Config's runtime.FunctionAnnotation.
+	// Set by tests.
+	runtime runtime.FunctionRuntime
+
+	checkInput func(string)
+}
+
+func (c ContainerFilter) String() string {
+	img := c.Image
+	if c.Config != nil {
+		img = fmt.Sprintf("%s %v", img, c.Config.MustString())
+	}
+	return img
+}
+
+// Filter filters Resources using a container image. It is equivalent to
+// calling FilterContext with context.Background(), bounded by c.Timeout if
+// one is set.
+func (c *ContainerFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	return c.FilterContext(context.Background(), nodes)
+}
+
+// FilterContext filters Resources using a container image, same as Filter,
+// but aborts the function invocation when ctx is done, returning a wrapped
+// context.DeadlineExceeded or context.Canceled.
+func (c *ContainerFilter) FilterContext(ctx context.Context, nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	out, _, err := c.invoke(ctx, nodes)
+	return out, err
+}
+
+// FilterResults is the same as FilterContext, but also returns any
+// `results` the function emitted on its output ResourceList, e.g. the
+// findings of a validator-style KRM function that reports non-fatal
+// failures instead of only exiting non-zero. It implements
+// kio.ResultsFilter.
+func (c *ContainerFilter) FilterResults(ctx context.Context, nodes []*yaml.RNode) ([]*yaml.RNode, kio.Results, error) {
+	return c.invoke(ctx, nodes)
+}
+
+// invoke runs the function once and parses both its Resources and its
+// results field from the output ResourceList. It bounds ctx by c.Timeout,
+// if one is set, regardless of which of Filter, FilterContext or
+// FilterResults the caller used to reach it, so Timeout is honored even
+// when a kio.Pipeline dispatches straight to FilterResults.
+func (c *ContainerFilter) invoke(ctx context.Context, nodes []*yaml.RNode) ([]*yaml.RNode, kio.Results, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	in := &bytes.Buffer{}
+	w := kio.ByteWriter{Writer: in, KeepReaderAnnotations: true, FunctionConfig: c.Config}
+	if err := w.Write(nodes); err != nil {
+		return nil, nil, err
+	}
+	if c.checkInput != nil {
+		c.checkInput(in.String())
+	}
+
+	fn, err := c.functionRuntime()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := &bytes.Buffer{}
+	if err := fn.Run(ctx, in, out); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, fmt.Errorf("kyaml: function %s: %w", c, ctxErr)
+		}
+		return nil, nil, err
+	}
+
+	results, err := parseResults(out.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := (&kio.ByteReader{Reader: bytes.NewReader(out.Bytes())}).Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	return output, results, nil
+}
+
+// parseResults extracts the `results` field from a function's output
+// ResourceList, if one is present.
+func parseResults(raw []byte) (kio.Results, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	rl, err := yaml.Parse(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	resultsField, err := rl.Pipe(yaml.Lookup("results"))
+	if err != nil || resultsField == nil {
+		return nil, err
+	}
+
+	var results kio.Results
+	if err := resultsField.YNode().Decode(&results); err != nil {
+		return nil, fmt.Errorf("parsing results: %w", err)
+	}
+	return results, nil
+}
+
+// functionRuntime resolves the runtime.FunctionRuntime used to invoke the
+// function: an explicit test override, the runtime named by Config's
+// runtime.FunctionAnnotation, or a runtime.DockerRuntime running Image.
+// c's sandboxing options are applied regardless of which of those is
+// chosen, so selecting a runtime via the annotation doesn't bypass them.
+func (c *ContainerFilter) functionRuntime() (runtime.FunctionRuntime, error) {
+	if c.runtime != nil {
+		return c.runtime, nil
+	}
+	opts := runtime.ContainerOptions{
+		Network:                c.Network,
+		StorageMounts:          c.StorageMounts,
+		EnvAllowlist:           c.EnvAllowlist,
+		MemoryLimit:            c.MemoryLimit,
+		CPULimit:               c.CPULimit,
+		AdditionalCapabilities: c.AdditionalCapabilities,
+	}
+	fn, err := runtime.Select(c.Config, opts)
+	if err != nil {
+		return nil, err
+	}
+	if fn != nil {
+		return fn, nil
+	}
+	return &runtime.DockerRuntime{
+		Image:                  c.Image,
+		Network:                c.Network,
+		StorageMounts:          c.StorageMounts,
+		EnvAllowlist:           c.EnvAllowlist,
+		MemoryLimit:            c.MemoryLimit,
+		CPULimit:               c.CPULimit,
+		AdditionalCapabilities: c.AdditionalCapabilities,
+	}, nil
+}
+
+// IsParallelSafe implements kio.ParallelFilter, reporting ParallelSafe.
+// Each invocation of a ContainerFilter starts its own container and
+// shares no state with other invocations, so it's safe for a kio.Pipeline
+// to run one container per Resource group concurrently, capped by
+// Pipeline.Parallelism -- but only for functions the caller has opted in
+// via ParallelSafe, since Pipeline.Parallelism/GroupBy apply uniformly to
+// every ParallelFilter in its Filters, and not every function can work
+// from a sharded view of the ResourceList.
+func (c *ContainerFilter) IsParallelSafe() bool {
+	return c.ParallelSafe
+}
+
+// containerImagePrefixes are apiVersion prefixes recognized as referring to
+// a container image rather than a Kubernetes API group.
+var containerImagePrefixes = []string{"gcr.io/", "us.gcr.io/", "docker.io/"}
+
+// GetContainerName returns the container image to use to run the function
+// for the given FunctionConfig: either an explicit containerAnnotation, or
+// an apiVersion that looks like a container image reference.
+func GetContainerName(n *yaml.RNode) string {
+	meta, err := n.GetMeta()
+	if err != nil {
+		return ""
+	}
+	if img := meta.Annotations[containerAnnotation]; img != "" {
+		return img
+	}
+	for _, prefix := range containerImagePrefixes {
+		if strings.HasPrefix(meta.APIVersion, prefix) {
+			return meta.APIVersion
+		}
+	}
+	return ""
+}
@@ -5,61 +5,17 @@ package filters
 
 import (
 	"bytes"
-	"os"
-	"strings"
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/runtime"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
-func TestFilter_command(t *testing.T) {
-	cfg, err := yaml.Parse(`apiversion: apps/v1
-kind: Deployment
-metadata:
-  name: foo
-`)
-	if !assert.NoError(t, err) {
-		return
-	}
-	instance := &ContainerFilter{
-		Image:  "example.com:version",
-		Config: cfg,
-	}
-	os.Setenv("KYAML_TEST", "FOO")
-	cmd, err := instance.getCommand()
-	if !assert.NoError(t, err) {
-		return
-	}
-
-	expected := []string{
-		"docker", "run",
-		"--rm",
-		"-i", "-a", "STDIN", "-a", "STDOUT", "-a", "STDERR",
-		"--network", "none",
-		"--user", "nobody",
-		"--security-opt=no-new-privileges",
-	}
-	for _, e := range os.Environ() {
-		// the process env
-		expected = append(expected, "-e", strings.Split(e, "=")[0])
-	}
-	expected = append(expected, "example.com:version")
-	assert.Equal(t, expected, cmd.Args)
-
-	foundKyaml := false
-	for _, e := range cmd.Env {
-		// verify the command has the right environment variables to pass to the container
-		split := strings.Split(e, "=")
-		if split[0] == "KYAML_TEST" {
-			assert.Equal(t, "FOO", split[1])
-			foundKyaml = true
-		}
-	}
-	assert.True(t, foundKyaml)
-}
-
 func TestFilter_Filter(t *testing.T) {
 	cfg, err := yaml.Parse(`apiVersion: apps/v1
 kind: Deployment
@@ -87,9 +43,9 @@ metadata:
 
 	called := false
 	result, err := (&ContainerFilter{
-		Image:  "example.com:version",
-		Config: cfg,
-		args:   []string{"sed", "s/Deployment/StatefulSet/g"},
+		Image:   "example.com:version",
+		Config:  cfg,
+		runtime: &runtime.ExecRuntime{Path: "sed", Args: []string{"s/Deployment/StatefulSet/g"}},
 		checkInput: func(s string) {
 			called = true
 			if !assert.Equal(t, `apiVersion: kyaml.kustomize.dev/v1alpha1
@@ -169,9 +125,9 @@ metadata:
 
 	called := false
 	result, err := (&ContainerFilter{
-		Image:  "example.com:version",
-		Config: cfg,
-		args:   []string{"sh", "-c", "cat <&0"},
+		Image:   "example.com:version",
+		Config:  cfg,
+		runtime: &runtime.ExecRuntime{Path: "sh", Args: []string{"-c", "cat <&0"}},
 		checkInput: func(s string) {
 			called = true
 			if !assert.Equal(t, `apiVersion: kyaml.kustomize.dev/v1alpha1
@@ -224,6 +180,94 @@ metadata:
 `, b.String())
 }
 
+func TestFilter_FilterResults(t *testing.T) {
+	cfg, err := yaml.Parse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deployment-foo
+`)}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	output := `apiVersion: kyaml.kustomize.dev/v1alpha1
+kind: ResourceList
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: deployment-foo
+    annotations:
+      kyaml.kustomize.dev/kio/index: 0
+results:
+- severity: error
+  message: must set spec.replicas
+  field: spec.replicas
+`
+
+	cf := &ContainerFilter{
+		Image:   "example.com:version",
+		Config:  cfg,
+		runtime: &runtime.ExecRuntime{Path: "printf", Args: []string{"%s", output}},
+	}
+
+	nodes, results, err := cf.FilterResults(context.Background(), input)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, nodes, 1)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, kio.SeverityError, results[0].Severity)
+		assert.Equal(t, "must set spec.replicas", results[0].Message)
+	}
+}
+
+// TestFilter_FilterResults_timeout asserts that Timeout is honored when a
+// caller reaches ContainerFilter through FilterResults -- e.g. a
+// kio.Pipeline dispatching straight to it because it implements
+// kio.ResultsFilter -- and not only through Filter.
+func TestFilter_FilterResults_timeout(t *testing.T) {
+	input, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deployment-foo
+`)}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cf := &ContainerFilter{
+		Image:   "example.com:version",
+		Timeout: 20 * time.Millisecond,
+		runtime: &runtime.ExecRuntime{Path: "sleep", Args: []string{"5"}},
+	}
+
+	start := time.Now()
+	_, _, err = cf.FilterResults(context.Background(), input)
+	elapsed := time.Since(start)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, elapsed, 2*time.Second,
+		"FilterResults should have aborted once Timeout elapsed instead of waiting for the function to exit on its own")
+}
+
+func TestFilter_IsParallelSafe(t *testing.T) {
+	assert.False(t, (&ContainerFilter{}).IsParallelSafe(),
+		"a ContainerFilter must opt into parallel dispatch explicitly, since not every function can work from a sharded ResourceList")
+	assert.True(t, (&ContainerFilter{ParallelSafe: true}).IsParallelSafe())
+}
+
 func Test_GetContainerName(t *testing.T) {
 	// make sure gcr.io works
 	n, err := yaml.Parse(`apiVersion: gcr.io/foo/bar:something
@@ -6,6 +6,8 @@
 package kio
 
 import (
+	"context"
+
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -52,6 +54,38 @@ func (fn FilterFunc) Filter(o []*yaml.RNode) ([]*yaml.RNode, error) {
 	return fn(o)
 }
 
+// ContextFilter is implemented by Filters whose work can be bounded by a
+// context, e.g. a Filter that invokes a long-running KRM function in a
+// child process. Pipeline.ExecuteContext calls FilterContext instead of
+// Filter for any Filter implementing this interface (or FilterResults, if
+// the Filter also implements ResultsFilter), so a caller-supplied
+// deadline or cancellation can abort an in-flight Filter.
+type ContextFilter interface {
+	FilterContext(ctx context.Context, o []*yaml.RNode) ([]*yaml.RNode, error)
+}
+
+// runFilter invokes f through the most capable entry point it implements,
+// so that a Filter implementing more than one of ResultsFilter,
+// ContextFilter and the plain Filter interface doesn't lose any of them:
+// ResultsFilter is preferred since it also surfaces Results, falling back
+// to ContextFilter for ctx-awareness and finally plain Filter. It is the
+// single call path ExecuteContext and filterParallel both route through,
+// so dispatching to a ParallelFilter's groups never bypasses a Filter's
+// Results or context handling.
+func runFilter(ctx context.Context, f Filter, nodes []*yaml.RNode) ([]*yaml.RNode, Results, error) {
+	switch tf := f.(type) {
+	case ResultsFilter:
+		out, results, err := tf.FilterResults(ctx, nodes)
+		return out, results, err
+	case ContextFilter:
+		out, err := tf.FilterContext(ctx, nodes)
+		return out, nil, err
+	default:
+		out, err := f.Filter(nodes)
+		return out, nil, err
+	}
+}
+
 // Pipeline reads Resource Configuration from a set of Inputs, applies some
 // transformations, and writes the results to a set of Outputs.
 //
@@ -67,10 +101,41 @@ type Pipeline struct {
 
 	// Outputs are where the transformed Resource Configuration is written.
 	Outputs []Writer `yaml:"outputs,omitempty"`
+
+	// Parallelism bounds how many groups of Resources are processed
+	// concurrently by a Filter implementing ParallelFilter. A value <= 1
+	// (the default) disables parallelism; Filters run serially over the
+	// full Resource slice regardless of whether they implement
+	// ParallelFilter.
+	Parallelism int `yaml:"parallelism,omitempty"`
+
+	// GroupBy partitions Resources into independent groups before they are
+	// handed to a ParallelFilter. When nil, Resources are grouped by GVK
+	// and namespace.
+	GroupBy GroupKeyFunc `yaml:"-"`
+
+	// ResultsAggregator, if set, receives the Results surfaced by any
+	// Filter implementing ResultsFilter as the Pipeline executes. Results
+	// are a channel for non-fatal findings alongside a Filter's normal
+	// output; ExecuteContext aborts with a *ResultsError once the Results
+	// accumulated across all Filters contain a SeverityError entry.
+	ResultsAggregator ResultsAggregator `yaml:"-"`
 }
 
-// Execute implements the Pipeline pipeline.
+// Execute implements the Pipeline pipeline. It is equivalent to calling
+// ExecuteContext with context.Background().
 func (p Pipeline) Execute() error {
+	return p.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is the same as Execute, but aborts once ctx is done. ctx
+// is checked before each Filter runs, and if it is done ctx.Err() (e.g.
+// context.DeadlineExceeded or context.Canceled) is returned unwrapped; a
+// Filter that observes ctx itself may return its own error for it first.
+// Filters implementing ContextFilter have ctx passed through
+// to them so they can cancel their own in-flight work, such as a child
+// process invoked by a KRM function.
+func (p Pipeline) ExecuteContext(ctx context.Context) error {
 	var result []*yaml.RNode
 
 	// read from the inputs
@@ -88,12 +153,34 @@ func (p Pipeline) Execute() error {
 
 	// apply operations
 	var err error
+	var accumulated Results
 	for i := range p.Filters {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		op := p.Filters[i]
-		result, err = op.Filter(result)
-		if len(result) == 0 || err != nil {
+		var filterResults Results
+		if pf, ok := op.(ParallelFilter); ok && p.Parallelism > 1 && pf.IsParallelSafe() {
+			result, filterResults, err = filterParallel(ctx, op, p.Parallelism, p.GroupBy, result)
+		} else {
+			result, filterResults, err = runFilter(ctx, op, result)
+		}
+
+		if len(filterResults) > 0 {
+			if p.ResultsAggregator != nil {
+				p.ResultsAggregator.Aggregate(filterResults)
+			}
+			accumulated = append(accumulated, filterResults...)
+		}
+		if err != nil {
 			return err
 		}
+		if accumulated.HasError() {
+			return &ResultsError{Results: accumulated}
+		}
+		if len(result) == 0 {
+			return nil
+		}
 	}
 
 	// write to the outputs
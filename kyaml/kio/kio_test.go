@@ -0,0 +1,257 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// blockingFilter implements ContextFilter and blocks until ctx is done,
+// so tests can assert that Pipeline.ExecuteContext actually propagates
+// cancellation into a Filter rather than just returning ctx.Err() once
+// the Filter finishes on its own.
+type blockingFilter struct{}
+
+func (f *blockingFilter) FilterContext(ctx context.Context, o []*yaml.RNode) ([]*yaml.RNode, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *blockingFilter) Filter(o []*yaml.RNode) ([]*yaml.RNode, error) {
+	return o, nil
+}
+
+func TestPipeline_ExecuteContext_timeout(t *testing.T) {
+	input, err := (&ByteReader{Reader: bytes.NewBufferString(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`)}).Read()
+	assert.NoError(t, err)
+
+	f := &blockingFilter{}
+	p := Pipeline{
+		Inputs:  []Reader{ResourceNodeSlice(input)},
+		Filters: []Filter{f},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = p.ExecuteContext(ctx)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, elapsed, time.Second,
+		"ExecuteContext should return as soon as the blocking Filter observes ctx.Done, not hang indefinitely")
+}
+
+// groupAwareFilter implements Filter, ParallelFilter and ResultsFilter all
+// at once, mirroring ContainerFilter, so tests can tell whether Pipeline
+// dispatches it through filterParallel (tracked via maxConcurrent) while
+// still surfacing its Results, rather than one capability silently
+// pre-empting the other.
+type groupAwareFilter struct {
+	mu            sync.Mutex
+	concurrent    int
+	maxConcurrent int
+}
+
+func (f *groupAwareFilter) FilterResults(ctx context.Context, nodes []*yaml.RNode) ([]*yaml.RNode, Results, error) {
+	f.mu.Lock()
+	f.concurrent++
+	if f.concurrent > f.maxConcurrent {
+		f.maxConcurrent = f.concurrent
+	}
+	f.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	f.mu.Lock()
+	f.concurrent--
+	f.mu.Unlock()
+
+	results := Results{{Severity: SeverityWarning, Message: fmt.Sprintf("checked %d resources", len(nodes))}}
+	return nodes, results, nil
+}
+
+func (f *groupAwareFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	out, _, err := f.FilterResults(context.Background(), nodes)
+	return out, err
+}
+
+func (f *groupAwareFilter) IsParallelSafe() bool { return true }
+
+func TestPipeline_ExecuteContext_parallelWithResults(t *testing.T) {
+	input, err := (&ByteReader{Reader: bytes.NewBufferString(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: bar
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: baz
+`)}).Read()
+	assert.NoError(t, err)
+
+	f := &groupAwareFilter{}
+	var mu sync.Mutex
+	var aggregated Results
+	p := Pipeline{
+		Inputs:      []Reader{ResourceNodeSlice(input)},
+		Filters:     []Filter{f},
+		Parallelism: 3,
+		ResultsAggregator: ResultsAggregatorFunc(func(r Results) {
+			mu.Lock()
+			defer mu.Unlock()
+			aggregated = append(aggregated, r...)
+		}),
+	}
+
+	assert.NoError(t, p.ExecuteContext(context.Background()))
+
+	assert.Greater(t, f.maxConcurrent, 1,
+		"the three distinct-kind Resource groups should have been dispatched to filterParallel concurrently")
+	assert.Len(t, aggregated, 3,
+		"each of the three groups' FilterResults should have surfaced its own Result to the ResultsAggregator")
+}
+
+// reverseDelayFilter sleeps longer for a group with a smaller indexAnnotation,
+// so whichever group filterParallel dispatches last is the one that
+// finishes first. If filterParallel merged results in goroutine-completion
+// order instead of sorting by indexAnnotation, this would reorder the
+// output relative to the input.
+type reverseDelayFilter struct{}
+
+func (reverseDelayFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	idx := resourceIndex(nodes[0])
+	time.Sleep(30*time.Millisecond - time.Duration(idx)*10*time.Millisecond)
+	return nodes, nil
+}
+
+func (reverseDelayFilter) IsParallelSafe() bool { return true }
+
+// TestPipeline_ExecuteContext_parallelPreservesOrder round-trips Resources
+// through the real ByteReader (which is what actually sets indexAnnotation
+// on each Resource, not a hand-constructed fixture) and asserts that a
+// Parallelism > 1 run restores the original input order regardless of
+// which group's goroutine happens to finish first.
+func TestPipeline_ExecuteContext_parallelPreservesOrder(t *testing.T) {
+	input, err := (&ByteReader{Reader: bytes.NewBufferString(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: second
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: third
+`)}).Read()
+	assert.NoError(t, err)
+
+	p := Pipeline{
+		Inputs:      []Reader{ResourceNodeSlice(input)},
+		Filters:     []Filter{reverseDelayFilter{}},
+		Parallelism: 3,
+	}
+
+	out := &captureWriter{}
+	p.Outputs = []Writer{out}
+
+	assert.NoError(t, p.ExecuteContext(context.Background()))
+
+	var names []string
+	for _, n := range out.nodes {
+		meta, err := n.GetMeta()
+		assert.NoError(t, err)
+		names = append(names, meta.Name)
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, names,
+		"output order should follow indexAnnotation, not goroutine completion order")
+}
+
+type captureWriter struct {
+	nodes []*yaml.RNode
+}
+
+func (w *captureWriter) Write(nodes []*yaml.RNode) error {
+	w.nodes = nodes
+	return nil
+}
+
+// errorResultsFilter implements ResultsFilter, always surfacing a
+// SeverityError Result. When keep is false it also strips every input
+// Resource, mimicking a validator that rejects everything it's given --
+// the exact shape that once let a *ResultsError go unreturned because the
+// len(result) == 0 check ran before accumulated.HasError().
+type errorResultsFilter struct {
+	keep bool
+}
+
+func (f errorResultsFilter) FilterResults(ctx context.Context, nodes []*yaml.RNode) ([]*yaml.RNode, Results, error) {
+	results := Results{{Severity: SeverityError, Message: "validation failed"}}
+	if f.keep {
+		return nodes, results, nil
+	}
+	return nil, results, nil
+}
+
+func (f errorResultsFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	out, _, err := f.FilterResults(context.Background(), nodes)
+	return out, err
+}
+
+func TestPipeline_ExecuteContext_resultsError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		keep bool
+	}{
+		{name: "withRemainingOutput", keep: true},
+		{name: "allResourcesStripped", keep: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			input, err := (&ByteReader{Reader: bytes.NewBufferString(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`)}).Read()
+			assert.NoError(t, err)
+
+			p := Pipeline{
+				Inputs:  []Reader{ResourceNodeSlice(input)},
+				Filters: []Filter{errorResultsFilter{keep: tc.keep}},
+			}
+
+			err = p.ExecuteContext(context.Background())
+			var resultsErr *ResultsError
+			if assert.ErrorAs(t, err, &resultsErr) {
+				assert.True(t, resultsErr.Results.HasError())
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// indexAnnotation is the annotation ByteReader sets on each Resource to
+// record its original position in the input stream, so ByteWriter can
+// reproduce a stable order even after Resources are reshuffled by a Filter.
+// This must match the key ByteReader itself emits -- see the annotations
+// ByteWriter round-trips in filters.TestFilter_Filter's fixture -- not the
+// config.kubernetes.io/index convention some other KRM-functions tooling
+// uses, which this package's ByteReader does not set.
+const indexAnnotation = "kyaml.kustomize.dev/kio/index"
+
+// ParallelFilter is implemented by Filters whose Filter method is safe to
+// invoke concurrently against disjoint groups of Resources -- e.g. because
+// each invocation spawns its own KRM function container and keeps no state
+// shared across groups. Pipeline only dispatches to groups concurrently
+// when Parallelism > 1 and the configured Filter implements this interface
+// with IsParallelSafe returning true; all other Filters continue to run
+// serially over the full Resource slice.
+type ParallelFilter interface {
+	Filter
+
+	// IsParallelSafe reports whether this Filter instance may be invoked
+	// concurrently across independent Resource groups.
+	IsParallelSafe() bool
+}
+
+// GroupKeyFunc computes the key Pipeline uses to partition Resources into
+// independent groups before handing them to a ParallelFilter. Resources
+// that return the same key are processed together, in a single group, and
+// in their original relative order.
+type GroupKeyFunc func(node *yaml.RNode) (string, error)
+
+// defaultGroupKey partitions Resources by GVK and namespace, which is a
+// reasonable default for independence: most Filters that mutate a single
+// Resource in place don't need to observe Resources of other kinds.
+func defaultGroupKey(node *yaml.RNode) (string, error) {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return "", err
+	}
+	return meta.APIVersion + "/" + meta.Kind + "/" + meta.Namespace, nil
+}
+
+// filterParallel partitions nodes using groupBy (defaultGroupKey if nil),
+// runs f over each group concurrently with at most parallelism groups in
+// flight at once, and merges the results back into a single slice ordered
+// by indexAnnotation so that ByteWriter's output order stays stable. Each
+// group is dispatched through runFilter, so a Filter that also implements
+// ResultsFilter or ContextFilter gets the same Results-surfacing and
+// context-honoring behavior per group as it would running serially.
+func filterParallel(ctx context.Context, f Filter, parallelism int, groupBy GroupKeyFunc, nodes []*yaml.RNode) ([]*yaml.RNode, Results, error) {
+	if groupBy == nil {
+		groupBy = defaultGroupKey
+	}
+
+	var order []string
+	groups := map[string][]*yaml.RNode{}
+	for _, n := range nodes {
+		key, err := groupBy(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, found := groups[key]; !found {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], n)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		sem        = make(chan struct{}, parallelism)
+		mu         sync.Mutex
+		result     []*yaml.RNode
+		allResults Results
+		firstErr   error
+	)
+	for _, key := range order {
+		group := groups[key]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(group []*yaml.RNode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var out []*yaml.RNode
+			var groupResults Results
+			var err error
+			if ctx.Err() == nil {
+				out, groupResults, err = runFilter(ctx, f, group)
+			} else {
+				err = ctx.Err()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result = append(result, out...)
+			allResults = append(allResults, groupResults...)
+		}(group)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, allResults, firstErr
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return resourceIndex(result[i]) < resourceIndex(result[j])
+	})
+	return result, allResults, nil
+}
+
+// resourceIndex reads the indexAnnotation from a Resource, defaulting to
+// the largest possible index (sorting last) when it is missing or invalid,
+// so newly introduced Resources without an index don't disrupt the order
+// of the ones that have one.
+func resourceIndex(node *yaml.RNode) int {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	v, found := meta.Annotations[indexAnnotation]
+	if !found {
+		return int(^uint(0) >> 1)
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return i
+}
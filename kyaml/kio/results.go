@@ -0,0 +1,104 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Severity indicates how serious a Result is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Result is a single, non-fatal finding emitted by a Filter, e.g. a lint
+// or validation failure surfaced by a KRM function that doesn't by itself
+// need to abort the Pipeline.
+type Result struct {
+	// Severity is how serious the finding is. A Pipeline aborts with a
+	// ResultsError once any Result it has accumulated has SeverityError.
+	Severity Severity `yaml:"severity,omitempty"`
+
+	// Message is a human readable description of the finding.
+	Message string `yaml:"message,omitempty"`
+
+	// ResourceRef identifies the Resource the finding is about, if any.
+	ResourceRef *yaml.ResourceIdentifier `yaml:"resourceRef,omitempty"`
+
+	// Field is a field path within ResourceRef the finding is about, e.g.
+	// "spec.replicas".
+	Field string `yaml:"field,omitempty"`
+
+	// File is the path the affected Resource was read from, if known.
+	File string `yaml:"file,omitempty"`
+}
+
+// Results is a list of Results, e.g. the findings surfaced by a single
+// Filter invocation.
+type Results []*Result
+
+// HasError reports whether any Result in r has SeverityError.
+func (r Results) HasError() bool {
+	for _, res := range r {
+		if res.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Results) Error() string {
+	var msgs []string
+	for _, res := range r {
+		msg := string(res.Severity) + ": " + res.Message
+		if res.File != "" {
+			msg = res.File + ": " + msg
+		}
+		msgs = append(msgs, msg)
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ResultsError is returned by Pipeline.ExecuteContext once the Results
+// accumulated from its Filters contain an entry with SeverityError,
+// letting callers recover the full set of findings rather than just the
+// first error.
+type ResultsError struct {
+	Results Results
+}
+
+func (e *ResultsError) Error() string {
+	return e.Results.Error()
+}
+
+// ResultsAggregator receives the Results surfaced by Filters as a Pipeline
+// executes, e.g. to print rich diagnostics with file/field context as soon
+// as they're available rather than only once the Pipeline finishes.
+type ResultsAggregator interface {
+	Aggregate(r Results)
+}
+
+// ResultsAggregatorFunc can be used to implement ResultsAggregator with a function.
+type ResultsAggregatorFunc func(Results)
+
+func (fn ResultsAggregatorFunc) Aggregate(r Results) {
+	fn(r)
+}
+
+// ResultsFilter is implemented by Filters that can surface non-fatal
+// findings in addition to transforming Resources, e.g. a KRM function
+// that validates Resources and reports failures as Results rather than by
+// returning an error. Pipeline.ExecuteContext forwards these to its
+// ResultsAggregator, if one is configured, and folds them into the
+// ResultsError it returns once any entry has SeverityError.
+type ResultsFilter interface {
+	FilterResults(ctx context.Context, o []*yaml.RNode) ([]*yaml.RNode, Results, error)
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Mount is a single bind, volume or tmpfs mount exposed to a container,
+// e.g. so a function can read a mounted kustomization directory.
+type Mount struct {
+	// Type is the mount type: bind, volume or tmpfs.
+	Type string
+
+	// Src is the mount source: a host path for bind, a volume name for
+	// volume, or empty for tmpfs.
+	Src string
+
+	// Dst is the path the mount is made available at inside the container.
+	Dst string
+
+	// ReadOnly mounts Src read-only.
+	ReadOnly bool
+}
+
+func (m Mount) String() string {
+	s := fmt.Sprintf("type=%s,src=%s,dst=%s", m.Type, m.Src, m.Dst)
+	if m.ReadOnly {
+		s += ",readonly"
+	}
+	return s
+}
+
+// DockerRuntime invokes a KRM function by running Image with `docker run`.
+// The container must read the ResourceList from stdin and write the
+// transformed ResourceList to stdout; a non-zero exit indicates failure.
+type DockerRuntime struct {
+	// Image is the container image to run.
+	Image string
+
+	// Network is the container's network mode: none (the default), host,
+	// bridge, or a user-defined Docker network name. Functions that fetch
+	// remote bases or otherwise need connectivity must opt in explicitly.
+	Network string
+
+	// StorageMounts are bind/volume/tmpfs mounts exposed to the container.
+	StorageMounts []Mount
+
+	// EnvAllowlist restricts which of the parent process's environment
+	// variables are passed to the container. When empty, the full
+	// process environment is passed through.
+	EnvAllowlist []string
+
+	// MemoryLimit caps the container's memory, e.g. "512m". Empty means
+	// no limit.
+	MemoryLimit string
+
+	// CPULimit caps the container's CPU, e.g. "0.5". Empty means no
+	// limit.
+	CPULimit string
+
+	// AdditionalCapabilities are Linux capabilities added to the
+	// container beyond Docker's default set.
+	AdditionalCapabilities []string
+
+	// args overrides the constructed `docker run` invocation; set by
+	// tests in place of Image.
+	args []string
+}
+
+func (r *DockerRuntime) getArgs() []string {
+	if len(r.args) != 0 {
+		return r.args
+	}
+
+	network := r.Network
+	if network == "" {
+		network = "none"
+	}
+	args := []string{
+		"docker", "run",
+		"--rm",
+		"-i", "-a", "STDIN", "-a", "STDOUT", "-a", "STDERR",
+		"--network", network,
+		"--user", "nobody",
+		"--security-opt=no-new-privileges",
+	}
+
+	for _, m := range r.StorageMounts {
+		args = append(args, "--mount", m.String())
+	}
+	for _, c := range r.AdditionalCapabilities {
+		args = append(args, "--cap-add", c)
+	}
+	if r.MemoryLimit != "" {
+		args = append(args, "--memory", r.MemoryLimit)
+	}
+	if r.CPULimit != "" {
+		args = append(args, "--cpus", r.CPULimit)
+	}
+
+	if len(r.EnvAllowlist) > 0 {
+		for _, e := range r.EnvAllowlist {
+			args = append(args, "-e", e)
+		}
+	} else {
+		for _, e := range os.Environ() {
+			args = append(args, "-e", strings.Split(e, "=")[0])
+		}
+	}
+
+	return append(args, r.Image)
+}
+
+// Run implements FunctionRuntime.
+func (r *DockerRuntime) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	args := r.getArgs()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = os.Environ()
+	cmd.Stdin = in
+	cmd.Stdout = out
+	errOut := &bytes.Buffer{}
+	cmd.Stderr = errOut
+
+	if err := runContext(ctx, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s", err, errOut.String())
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerRuntime_getArgs(t *testing.T) {
+	os.Setenv("KYAML_TEST", "FOO")
+
+	r := &DockerRuntime{Image: "example.com:version"}
+	args := r.getArgs()
+
+	expected := []string{
+		"docker", "run",
+		"--rm",
+		"-i", "-a", "STDIN", "-a", "STDOUT", "-a", "STDERR",
+		"--network", "none",
+		"--user", "nobody",
+		"--security-opt=no-new-privileges",
+	}
+	for _, e := range os.Environ() {
+		expected = append(expected, "-e", strings.Split(e, "=")[0])
+	}
+	expected = append(expected, "example.com:version")
+	assert.Equal(t, expected, args)
+}
+
+func TestDockerRuntime_getArgs_sandboxing(t *testing.T) {
+	r := &DockerRuntime{
+		Image:   "example.com:version",
+		Network: "bridge",
+		StorageMounts: []Mount{
+			{Type: "bind", Src: "/tmp/kustomize", Dst: "/tmp/kustomize", ReadOnly: true},
+		},
+		EnvAllowlist:           []string{"HOME"},
+		MemoryLimit:            "512m",
+		CPULimit:               "0.5",
+		AdditionalCapabilities: []string{"NET_BIND_SERVICE"},
+	}
+	args := r.getArgs()
+
+	expected := []string{
+		"docker", "run",
+		"--rm",
+		"-i", "-a", "STDIN", "-a", "STDOUT", "-a", "STDERR",
+		"--network", "bridge",
+		"--user", "nobody",
+		"--security-opt=no-new-privileges",
+		"--mount", "type=bind,src=/tmp/kustomize,dst=/tmp/kustomize,readonly",
+		"--cap-add", "NET_BIND_SERVICE",
+		"--memory", "512m",
+		"--cpus", "0.5",
+		"-e", "HOME",
+		"example.com:version",
+	}
+	assert.Equal(t, expected, args)
+}
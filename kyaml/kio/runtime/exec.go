@@ -0,0 +1,44 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// ExecRuntime invokes a KRM function by running a locally installed binary
+// with the same ResourceList stdin/stdout contract as a container-based
+// function. It is useful in environments without Docker, e.g. some CI
+// images, and for running functions whose implementation has already been
+// compiled into the binary named by Path.
+type ExecRuntime struct {
+	// Path is the binary to run, resolved using the process's PATH.
+	Path string
+
+	// Args are passed to Path.
+	Args []string
+}
+
+// Run implements FunctionRuntime.
+func (r *ExecRuntime) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	cmd := exec.Command(r.Path, r.Args...)
+	cmd.Env = os.Environ()
+	cmd.Stdin = in
+	cmd.Stdout = out
+	errOut := &bytes.Buffer{}
+	cmd.Stderr = errOut
+
+	if err := runContext(ctx, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s", err, errOut.String())
+	}
+	return nil
+}
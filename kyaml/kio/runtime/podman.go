@@ -0,0 +1,113 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PodmanRuntime invokes a KRM function by running Image with `podman run`,
+// mirroring DockerRuntime's flags for environments that use podman as
+// their container runtime instead of Docker.
+type PodmanRuntime struct {
+	// Image is the container image to run.
+	Image string
+
+	// Network is the container's network mode: none (the default), host,
+	// bridge, or a user-defined podman network name.
+	Network string
+
+	// StorageMounts are bind/volume/tmpfs mounts exposed to the container.
+	StorageMounts []Mount
+
+	// EnvAllowlist restricts which of the parent process's environment
+	// variables are passed to the container. When empty, the full
+	// process environment is passed through.
+	EnvAllowlist []string
+
+	// MemoryLimit caps the container's memory, e.g. "512m". Empty means
+	// no limit.
+	MemoryLimit string
+
+	// CPULimit caps the container's CPU, e.g. "0.5". Empty means no
+	// limit.
+	CPULimit string
+
+	// AdditionalCapabilities are Linux capabilities added to the
+	// container beyond podman's default set.
+	AdditionalCapabilities []string
+
+	// args overrides the constructed `podman run` invocation; set by
+	// tests in place of Image.
+	args []string
+}
+
+func (r *PodmanRuntime) getArgs() []string {
+	if len(r.args) != 0 {
+		return r.args
+	}
+
+	network := r.Network
+	if network == "" {
+		network = "none"
+	}
+	args := []string{
+		"podman", "run",
+		"--rm",
+		"-i", "-a", "STDIN", "-a", "STDOUT", "-a", "STDERR",
+		"--network", network,
+		"--user", "nobody",
+		"--security-opt=no-new-privileges",
+	}
+
+	for _, m := range r.StorageMounts {
+		args = append(args, "--mount", m.String())
+	}
+	for _, c := range r.AdditionalCapabilities {
+		args = append(args, "--cap-add", c)
+	}
+	if r.MemoryLimit != "" {
+		args = append(args, "--memory", r.MemoryLimit)
+	}
+	if r.CPULimit != "" {
+		args = append(args, "--cpus", r.CPULimit)
+	}
+
+	if len(r.EnvAllowlist) > 0 {
+		for _, e := range r.EnvAllowlist {
+			args = append(args, "-e", e)
+		}
+	} else {
+		for _, e := range os.Environ() {
+			args = append(args, "-e", strings.Split(e, "=")[0])
+		}
+	}
+
+	return append(args, r.Image)
+}
+
+// Run implements FunctionRuntime.
+func (r *PodmanRuntime) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	args := r.getArgs()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = os.Environ()
+	cmd.Stdin = in
+	cmd.Stdout = out
+	errOut := &bytes.Buffer{}
+	cmd.Stderr = errOut
+
+	if err := runContext(ctx, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s", err, errOut.String())
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// terminationGracePeriod is how long a child process is given to exit
+// after receiving SIGTERM before it is forcibly killed with SIGKILL. It is
+// a var rather than a const so tests can shrink it instead of waiting out
+// the real grace period.
+var terminationGracePeriod = 5 * time.Second
+
+// runContext starts cmd and waits for it to finish, terminating it if ctx
+// is done before the process exits on its own: cmd.Process is sent
+// SIGTERM and given terminationGracePeriod to exit before being sent
+// SIGKILL. The returned error is ctx.Err() when ctx ended the process.
+func runContext(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-done:
+			return ctx.Err()
+		case <-time.After(terminationGracePeriod):
+			_ = cmd.Process.Kill()
+			<-done
+			return ctx.Err()
+		}
+	}
+}
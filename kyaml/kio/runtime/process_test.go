@@ -0,0 +1,59 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRunContext_sigkillAfterGracePeriod runs a child that traps and
+// ignores SIGTERM, so runContext can only stop it by escalating to
+// SIGKILL once terminationGracePeriod elapses. It asserts the process is
+// actually killed -- not just that ctx.Err() is returned -- by bounding
+// the whole call well under the child's own sleep duration.
+func TestRunContext_sigkillAfterGracePeriod(t *testing.T) {
+	orig := terminationGracePeriod
+	terminationGracePeriod = 20 * time.Millisecond
+	defer func() { terminationGracePeriod = orig }()
+
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 5")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := runContext(ctx, cmd)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, elapsed, 2*time.Second,
+		"runContext should have force-killed the child after the grace period instead of waiting out its sleep")
+}
+
+// TestRunContext_sigtermSufficient runs a child that exits promptly on
+// SIGTERM, so runContext should return without ever needing to escalate
+// to SIGKILL.
+func TestRunContext_sigtermSufficient(t *testing.T) {
+	orig := terminationGracePeriod
+	terminationGracePeriod = 2 * time.Second
+	defer func() { terminationGracePeriod = orig }()
+
+	cmd := exec.Command("sleep", "5")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := runContext(ctx, cmd)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, elapsed, terminationGracePeriod,
+		"a process that honors SIGTERM should exit well before the grace period elapses")
+}
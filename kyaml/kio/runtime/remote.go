@@ -0,0 +1,50 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RemoteRuntime invokes a KRM function by POSTing the ResourceList to a
+// long-lived function server and streaming the response body back as the
+// result. This amortizes container startup across many invocations from
+// the same Pipeline, at the cost of requiring the server to already be
+// running and reachable.
+type RemoteRuntime struct {
+	// Endpoint is the URL of the function server, e.g. http://localhost:8080.
+	Endpoint string
+
+	// Client is used to make the request. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Run implements FunctionRuntime.
+func (r *RemoteRuntime) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Endpoint, in)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("remote function %s returned %s: %s", r.Endpoint, resp.Status, body)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRemoteRuntime_Run_nonOKStatus asserts that a non-200 response aborts
+// the call and that the returned error surfaces the response body, so a
+// caller can see why the function server rejected the request.
+func TestRemoteRuntime_Run_nonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid ResourceList: missing apiVersion"))
+	}))
+	defer srv.Close()
+
+	r := &RemoteRuntime{Endpoint: srv.URL}
+	out := &bytes.Buffer{}
+	err := r.Run(context.Background(), bytes.NewBufferString("{}"), out)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "400")
+		assert.Contains(t, err.Error(), "invalid ResourceList: missing apiVersion")
+	}
+}
+
+// TestRemoteRuntime_Run_contextCanceled asserts that Run aborts promptly
+// once ctx is done mid-request instead of waiting for the function server
+// to respond.
+func TestRemoteRuntime_Run_contextCanceled(t *testing.T) {
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	r := &RemoteRuntime{Endpoint: srv.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := r.Run(ctx, bytes.NewBufferString("{}"), &bytes.Buffer{})
+	elapsed := time.Since(start)
+
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, elapsed, time.Second,
+		"Run should have aborted once ctx was done instead of waiting for the server's response")
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package runtime provides pluggable implementations of the KRM function
+// execution contract: write a ResourceList to stdin, read the transformed
+// ResourceList back from stdout. filters.ContainerFilter invokes a
+// FunctionRuntime rather than always shelling out to `docker run`, so
+// callers can run functions with podman, a locally installed binary, or a
+// long-lived remote function server without changing the Pipeline that
+// invokes them.
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// FunctionAnnotation, when set on a functionConfig, selects the
+// FunctionRuntime used to invoke it, taking precedence over whatever
+// default a caller (e.g. ContainerFilter.Image) would otherwise use. Its
+// value is a YAML block naming exactly one of container, podman, exec,
+// starlark or remote, e.g.:
+//
+//	metadata:
+//	  annotations:
+//	    config.kubernetes.io/function: |
+//	      container:
+//	        image: gcr.io/example/foo:v1.0.0
+const FunctionAnnotation = "config.kubernetes.io/function"
+
+// FunctionRuntime invokes a single KRM function: it writes the function's
+// ResourceList output to out as the function produces it, and returns once
+// the function exits or, if ctx is done first, once the invocation has been
+// stopped.
+type FunctionRuntime interface {
+	Run(ctx context.Context, in io.Reader, out io.Writer) error
+}
+
+// functionSpec is the schema of the FunctionAnnotation value. Exactly one
+// field should be set.
+type functionSpec struct {
+	Container *struct {
+		Image string `yaml:"image,omitempty"`
+	} `yaml:"container,omitempty"`
+
+	Podman *struct {
+		Image string `yaml:"image,omitempty"`
+	} `yaml:"podman,omitempty"`
+
+	Exec *struct {
+		Path string   `yaml:"path,omitempty"`
+		Args []string `yaml:"args,omitempty"`
+	} `yaml:"exec,omitempty"`
+
+	Starlark *struct {
+		Path string `yaml:"path,omitempty"`
+	} `yaml:"starlark,omitempty"`
+
+	Remote *struct {
+		URL string `yaml:"url,omitempty"`
+	} `yaml:"remote,omitempty"`
+}
+
+// ContainerOptions carries the sandboxing knobs a caller applies uniformly
+// to whichever container-based FunctionRuntime Select ends up choosing, so
+// that selecting a runtime via FunctionAnnotation doesn't silently drop
+// them in favor of each runtime's insecure defaults (full env passthrough,
+// no resource limits).
+type ContainerOptions struct {
+	// Network is the container's network mode: none (the default), host,
+	// bridge, or a user-defined network name.
+	Network string
+
+	// StorageMounts are bind/volume/tmpfs mounts exposed to the container.
+	StorageMounts []Mount
+
+	// EnvAllowlist restricts which of the parent process's environment
+	// variables are passed to the container. When empty, the full
+	// process environment is passed through.
+	EnvAllowlist []string
+
+	// MemoryLimit caps the container's memory, e.g. "512m". Empty means
+	// no limit.
+	MemoryLimit string
+
+	// CPULimit caps the container's CPU, e.g. "0.5". Empty means no
+	// limit.
+	CPULimit string
+
+	// AdditionalCapabilities are Linux capabilities added to the
+	// container beyond its runtime's default set.
+	AdditionalCapabilities []string
+}
+
+// Select returns the FunctionRuntime named by functionConfig's
+// FunctionAnnotation. It returns a nil FunctionRuntime and a nil error when
+// functionConfig is nil or has no such annotation, so callers can fall back
+// to their own default runtime (e.g. a container image named elsewhere).
+// opts is applied to whichever container-based runtime (container or
+// podman) the annotation selects, so a caller's sandboxing configuration
+// keeps applying regardless of which runtime a function config opts into.
+func Select(functionConfig *yaml.RNode, opts ContainerOptions) (FunctionRuntime, error) {
+	if functionConfig == nil {
+		return nil, nil
+	}
+	meta, err := functionConfig.GetMeta()
+	if err != nil {
+		return nil, err
+	}
+	raw, found := meta.Annotations[FunctionAnnotation]
+	if !found {
+		return nil, nil
+	}
+
+	var spec functionSpec
+	if err := yaml.Unmarshal([]byte(raw), &spec); err != nil {
+		return nil, fmt.Errorf("parsing %s annotation: %w", FunctionAnnotation, err)
+	}
+
+	switch {
+	case spec.Container != nil:
+		return &DockerRuntime{
+			Image:                  spec.Container.Image,
+			Network:                opts.Network,
+			StorageMounts:          opts.StorageMounts,
+			EnvAllowlist:           opts.EnvAllowlist,
+			MemoryLimit:            opts.MemoryLimit,
+			CPULimit:               opts.CPULimit,
+			AdditionalCapabilities: opts.AdditionalCapabilities,
+		}, nil
+	case spec.Podman != nil:
+		return &PodmanRuntime{
+			Image:                  spec.Podman.Image,
+			Network:                opts.Network,
+			StorageMounts:          opts.StorageMounts,
+			EnvAllowlist:           opts.EnvAllowlist,
+			MemoryLimit:            opts.MemoryLimit,
+			CPULimit:               opts.CPULimit,
+			AdditionalCapabilities: opts.AdditionalCapabilities,
+		}, nil
+	case spec.Exec != nil:
+		return &ExecRuntime{Path: spec.Exec.Path, Args: spec.Exec.Args}, nil
+	case spec.Starlark != nil:
+		return nil, fmt.Errorf("%s: starlark functions are not supported", FunctionAnnotation)
+	case spec.Remote != nil:
+		return &RemoteRuntime{Endpoint: spec.Remote.URL}, nil
+	default:
+		return nil, fmt.Errorf("%s annotation must specify one of container, podman, exec, starlark or remote", FunctionAnnotation)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestSelect(t *testing.T) {
+	noAnnotation, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+`)
+	assert.NoError(t, err)
+	fn, err := Select(noAnnotation, ContainerOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, fn)
+
+	container, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: gcr.io/example/foo:v1.0.0
+`)
+	assert.NoError(t, err)
+	fn, err = Select(container, ContainerOptions{})
+	assert.NoError(t, err)
+	if assert.IsType(t, &DockerRuntime{}, fn) {
+		assert.Equal(t, "gcr.io/example/foo:v1.0.0", fn.(*DockerRuntime).Image)
+	}
+
+	podman, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      podman:
+        image: gcr.io/example/foo:v1.0.0
+`)
+	assert.NoError(t, err)
+	fn, err = Select(podman, ContainerOptions{})
+	assert.NoError(t, err)
+	if assert.IsType(t, &PodmanRuntime{}, fn) {
+		assert.Equal(t, "gcr.io/example/foo:v1.0.0", fn.(*PodmanRuntime).Image)
+	}
+
+	exec, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: my-function
+        args: ["-v"]
+`)
+	assert.NoError(t, err)
+	fn, err = Select(exec, ContainerOptions{})
+	assert.NoError(t, err)
+	if assert.IsType(t, &ExecRuntime{}, fn) {
+		er := fn.(*ExecRuntime)
+		assert.Equal(t, "my-function", er.Path)
+		assert.Equal(t, []string{"-v"}, er.Args)
+	}
+
+	remote, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      remote:
+        url: http://localhost:8080
+`)
+	assert.NoError(t, err)
+	fn, err = Select(remote, ContainerOptions{})
+	assert.NoError(t, err)
+	if assert.IsType(t, &RemoteRuntime{}, fn) {
+		assert.Equal(t, "http://localhost:8080", fn.(*RemoteRuntime).Endpoint)
+	}
+}
+
+func TestSelect_appliesContainerOptions(t *testing.T) {
+	opts := ContainerOptions{
+		Network:                "bridge",
+		StorageMounts:          []Mount{{Type: "bind", Src: "/src", Dst: "/dst"}},
+		EnvAllowlist:           []string{"HOME"},
+		MemoryLimit:            "512m",
+		CPULimit:               "0.5",
+		AdditionalCapabilities: []string{"NET_BIND_SERVICE"},
+	}
+
+	container, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: gcr.io/example/foo:v1.0.0
+`)
+	assert.NoError(t, err)
+	fn, err := Select(container, opts)
+	assert.NoError(t, err)
+	if assert.IsType(t, &DockerRuntime{}, fn) {
+		dr := fn.(*DockerRuntime)
+		assert.Equal(t, opts.Network, dr.Network)
+		assert.Equal(t, opts.StorageMounts, dr.StorageMounts)
+		assert.Equal(t, opts.EnvAllowlist, dr.EnvAllowlist)
+		assert.Equal(t, opts.MemoryLimit, dr.MemoryLimit)
+		assert.Equal(t, opts.CPULimit, dr.CPULimit)
+		assert.Equal(t, opts.AdditionalCapabilities, dr.AdditionalCapabilities)
+	}
+
+	podman, err := yaml.Parse(`apiVersion: v1
+kind: MyConfig
+metadata:
+  annotations:
+    config.kubernetes.io/function: |
+      podman:
+        image: gcr.io/example/foo:v1.0.0
+`)
+	assert.NoError(t, err)
+	fn, err = Select(podman, opts)
+	assert.NoError(t, err)
+	if assert.IsType(t, &PodmanRuntime{}, fn) {
+		pr := fn.(*PodmanRuntime)
+		assert.Equal(t, opts.Network, pr.Network)
+		assert.Equal(t, opts.StorageMounts, pr.StorageMounts)
+		assert.Equal(t, opts.EnvAllowlist, pr.EnvAllowlist)
+		assert.Equal(t, opts.MemoryLimit, pr.MemoryLimit)
+		assert.Equal(t, opts.CPULimit, pr.CPULimit)
+		assert.Equal(t, opts.AdditionalCapabilities, pr.AdditionalCapabilities)
+	}
+}